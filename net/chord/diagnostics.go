@@ -0,0 +1,107 @@
+package chord
+
+import "errors"
+
+// ErrForbidden is returned by ListVnodes when the configured ACL hook
+// rejects the requesting host.
+var ErrForbidden = errors.New("chord: operation not permitted for this host")
+
+// ListVnodesACL decides whether remoteHost may call ListVnodes. A nil ACL
+// (the default) allows everyone, matching the previous unrestricted
+// behavior.
+type ListVnodesACL func(remoteHost string) bool
+
+// SetListVnodesACL restricts ListVnodes (and the diagnostics it feeds)
+// to hosts the hook approves, e.g. a trusted admin interface.
+func (r *Ring) SetListVnodesACL(fn ListVnodesACL) {
+	r.listVnodesACLMu.Lock()
+	defer r.listVnodesACLMu.Unlock()
+	r.listVnodesACL = fn
+}
+
+func (r *Ring) listVnodesAllowed(remoteHost string) bool {
+	r.listVnodesACLMu.Lock()
+	fn := r.listVnodesACL
+	r.listVnodesACLMu.Unlock()
+	if fn == nil {
+		return true
+	}
+	return fn(remoteHost)
+}
+
+// RPC: ListVnodes returns every vnode hosted by this ring instance, for
+// diagnostics and bootstrap tooling that wants to walk the full ring
+// starting from a seed host.
+func (r *Ring) ListVnodes(remoteHost string) ([]*Vnode, error) {
+	if !r.listVnodesAllowed(remoteHost) {
+		return nil, ErrForbidden
+	}
+
+	out := make([]*Vnode, len(r.vnodes))
+	for i, vn := range r.vnodes {
+		out[i] = &vn.Vnode
+	}
+	return out, nil
+}
+
+// VnodeSnapshot is the local view one vnode has of its neighborhood.
+type VnodeSnapshot struct {
+	Self        *VnodeData
+	Successors  []*VnodeData
+	Predecessor *VnodeData
+	Finger      []*VnodeData
+}
+
+// RingSnapshot is the full local ring view: every vnode hosted here and
+// what each believes its neighbors are. An external scraper can
+// reconstruct the global ring by walking ListVnodes from a seed.
+// SnapshotGraph builds this view for the local host only; no chord RPC
+// returns it.
+type RingSnapshot struct {
+	Vnodes []*VnodeSnapshot
+}
+
+// SnapshotGraph dumps, for each local vnode, its successor list,
+// predecessor, and deduplicated finger table as VnodeData.
+func (r *Ring) SnapshotGraph() *RingSnapshot {
+	snap := &RingSnapshot{Vnodes: make([]*VnodeSnapshot, len(r.vnodes))}
+
+	for i, vn := range r.vnodes {
+		succ := make([]*VnodeData, 0, len(vn.successors))
+		for _, s := range vn.successors {
+			if s != nil {
+				succ = append(succ, s.ToData())
+			}
+		}
+
+		snap.Vnodes[i] = &VnodeSnapshot{
+			Self:        vn.Vnode.ToData(),
+			Successors:  succ,
+			Predecessor: vn.predecessor.ToData(),
+			Finger:      dedupFingerData(vn),
+		}
+	}
+
+	return snap
+}
+
+// dedupFingerData collapses the finger table's many repeated entries
+// (fixFingerTableAtIndex skips ahead while a node covers several finger
+// slots) down to its unique members, same idea as toData() but without
+// padding back out to hashBits length.
+func dedupFingerData(vn *localVnode) []*VnodeData {
+	out := make([]*VnodeData, 0, len(vn.finger))
+	seen := make(map[string]bool)
+	for _, n := range vn.finger {
+		if n == nil {
+			continue
+		}
+		key := n.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, n.ToData())
+	}
+	return out
+}
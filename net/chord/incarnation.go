@@ -0,0 +1,90 @@
+package chord
+
+import (
+	"sync"
+
+	nlog "github.com/nknorg/nkn/util/log"
+)
+
+// Vnode now carries an Incarnation uint64, bumped every time a host
+// restarts and reclaims its (deterministic) chord Id. A higher
+// incarnation for the same Id always wins over a lower one, regardless
+// of ring position, so a restarted instance doesn't have to wait out a
+// suspicion timer to evict its own stale entries from neighbors' tables.
+
+// IncarnationStore persists the last incarnation number used for a
+// given chord Id across restarts. Config.IncarnationStore may be nil, in
+// which case incarnation numbers are only unique for the lifetime of the
+// process (no protection against flapping restarts within the same run).
+type IncarnationStore interface {
+	LoadIncarnation(id string) (uint64, error)
+	SaveIncarnation(id string, incarnation uint64) error
+}
+
+// memIncarnationStore is the fallback used when Config.IncarnationStore
+// is nil; it only guards against collisions within a single process.
+type memIncarnationStore struct {
+	mu   sync.Mutex
+	last map[string]uint64
+}
+
+func (s *memIncarnationStore) LoadIncarnation(id string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last[id], nil
+}
+
+func (s *memIncarnationStore) SaveIncarnation(id string, incarnation uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		s.last = make(map[string]uint64)
+	}
+	s.last[id] = incarnation
+	return nil
+}
+
+var fallbackIncarnationStore = &memIncarnationStore{}
+
+// initIncarnation loads the last persisted incarnation for vn's Id and
+// bumps it by one, so a process that crashes and restarts immediately
+// still outranks the entries it left behind in neighbors' tables.
+func (vn *localVnode) initIncarnation() {
+	store := vn.ring.config.IncarnationStore
+	if store == nil {
+		store = fallbackIncarnationStore
+	}
+
+	id := vn.String()
+	last, err := store.LoadIncarnation(id)
+	if err != nil {
+		nlog.Warnf("Failed to load incarnation for %s, starting at 0: %s", id, err)
+	}
+
+	vn.Incarnation = last + 1
+	if err := store.SaveIncarnation(id, vn.Incarnation); err != nil {
+		nlog.Warnf("Failed to persist incarnation for %s: %s", id, err)
+	}
+}
+
+// refuteSelfIfStale checks whether candidate claims to be this same vnode
+// (by Id) but announces a lower incarnation than our own - the telltale
+// sign of a split-brain restart race, where a stale copy of us is still
+// being referenced by a neighbor. Any RPC handler that receives a *Vnode
+// which could plausibly represent "us" (GetPredecessor can't, since it
+// carries no such argument) should call this so the stale incarnation
+// gets refuted instead of waiting out a suspicion timer.
+func (vn *localVnode) refuteSelfIfStale(candidate *Vnode) {
+	if candidate != nil && candidate.String() == vn.String() && candidate.Incarnation < vn.Incarnation {
+		vn.queueGossip(gossipMsg{Target: &vn.Vnode, Suspect: false})
+	}
+}
+
+// sameIdNewer reports whether candidate shares known's Id but carries a
+// strictly higher incarnation, meaning it's a fresher instance of the
+// same logical node rather than a different node entirely.
+func sameIdNewer(known, candidate *Vnode) bool {
+	return known != nil && candidate != nil &&
+		CompareId(known.Id, candidate.Id) == 0 &&
+		candidate.Incarnation > known.Incarnation
+}
@@ -0,0 +1,29 @@
+package chord
+
+import "testing"
+
+func TestSameIdNewer(t *testing.T) {
+	known := &Vnode{Id: []byte{1}, Incarnation: 2}
+
+	cases := []struct {
+		name      string
+		candidate *Vnode
+		want      bool
+	}{
+		{"higher incarnation, same id", &Vnode{Id: []byte{1}, Incarnation: 3}, true},
+		{"lower incarnation, same id", &Vnode{Id: []byte{1}, Incarnation: 1}, false},
+		{"equal incarnation, same id", &Vnode{Id: []byte{1}, Incarnation: 2}, false},
+		{"higher incarnation, different id", &Vnode{Id: []byte{2}, Incarnation: 3}, false},
+		{"nil candidate", nil, false},
+	}
+
+	for _, c := range cases {
+		if got := sameIdNewer(known, c.candidate); got != c.want {
+			t.Errorf("%s: sameIdNewer() = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	if sameIdNewer(nil, &Vnode{Id: []byte{1}, Incarnation: 9}) {
+		t.Error("nil known should never be considered newer")
+	}
+}
@@ -0,0 +1,337 @@
+package chord
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+
+	nlog "github.com/nknorg/nkn/util/log"
+)
+
+// InvokeArgs is the generic envelope every RPC in VnodeRPC travels as
+// over the wire - one net/rpc method ("Chord.Invoke") dispatched by
+// name, rather than one net/rpc method per RPC, since VnodeRPC keeps
+// growing as new chord features land.
+type InvokeArgs struct {
+	TargetId string
+	Method   string
+	Host     string // remoteHost for stabilize-class RPCs, topic for topic RPCs
+	N        int    // FindSuccessors/TopicQuery count
+	Key      []byte
+	Vn       *Vnode
+	Gossip   []gossipMsg
+	TTL      time.Duration
+}
+
+// InvokeReply carries back whichever of these fields the dispatched
+// method actually populates.
+type InvokeReply struct {
+	Vn     *Vnode
+	Vnodes []*Vnode
+	Gossip []gossipMsg
+	Ok     bool
+	ErrMsg string
+}
+
+func replyErr(reply *InvokeReply, err error) error {
+	if err != nil {
+		reply.ErrMsg = err.Error()
+	}
+	return nil
+}
+
+// decodeErr turns an error message that crossed the wire back into one
+// of our typed sentinel errors where possible, so callers can still
+// distinguish ErrBusy/ErrRateLimited from a dead peer after a round
+// trip through net/rpc.
+func decodeErr(msg string) error {
+	switch msg {
+	case "":
+		return nil
+	case ErrBusy.Error():
+		return ErrBusy
+	case ErrRateLimited.Error():
+		return ErrRateLimited
+	case ErrUnknownVnode.Error():
+		return ErrUnknownVnode
+	case ErrForbidden.Error():
+		return ErrForbidden
+	default:
+		return errors.New(msg)
+	}
+}
+
+// chordRPCService is what's registered with net/rpc; it just looks up
+// the target Vnode in the transport's local registry and calls straight
+// into its VnodeRPC implementation.
+type chordRPCService struct {
+	t *TCPTransport
+}
+
+func (s *chordRPCService) Invoke(args *InvokeArgs, reply *InvokeReply) error {
+	o, ok := s.t.lookupByID(args.TargetId)
+	if !ok {
+		reply.ErrMsg = ErrUnknownVnode.Error()
+		return nil
+	}
+
+	switch args.Method {
+	case "Ping":
+		ok2, err := o.Ping()
+		reply.Ok = ok2
+		return replyErr(reply, err)
+	case "GetPredecessor":
+		pred, err := o.GetPredecessor(args.Host)
+		reply.Vn = pred
+		return replyErr(reply, err)
+	case "Notify":
+		succs, gossip, err := o.Notify(args.Host, args.Vn, args.Gossip)
+		reply.Vnodes = succs
+		reply.Gossip = gossip
+		return replyErr(reply, err)
+	case "FindSuccessors":
+		succs, err := o.FindSuccessors(args.Host, args.N, args.Key)
+		reply.Vnodes = succs
+		return replyErr(reply, err)
+	case "ClearPredecessor":
+		return replyErr(reply, o.ClearPredecessor(args.Host, args.Vn))
+	case "SkipSuccessor":
+		return replyErr(reply, o.SkipSuccessor(args.Host, args.Vn))
+	case "IndirectPing":
+		ok2, err := o.IndirectPing(args.Vn)
+		reply.Ok = ok2
+		return replyErr(reply, err)
+	case "RegisterTopic":
+		return replyErr(reply, o.RegisterTopic(args.Host, args.Vn, args.TTL))
+	case "TopicQuery":
+		vns, err := o.TopicQuery(args.Host, args.N)
+		reply.Vnodes = vns
+		return replyErr(reply, err)
+	case "ListVnodes":
+		vns, err := o.ListVnodes(args.Host)
+		reply.Vnodes = vns
+		return replyErr(reply, err)
+	default:
+		reply.ErrMsg = "chord: unknown RPC method " + args.Method
+		return nil
+	}
+}
+
+// TCPTransport is a Transport that serves locally-registered Vnodes
+// over the network and reaches everyone else via net/rpc. Locally
+// registered targets are served in-process without touching the
+// network, via the embedded LocalTransport.
+type TCPTransport struct {
+	*LocalTransport
+
+	listener net.Listener
+	server   *rpc.Server
+
+	clientsMu sync.Mutex
+	clients   map[string]*rpc.Client
+}
+
+// NewTCPTransport starts listening on listenAddr and begins accepting
+// connections in the background.
+func NewTCPTransport(listenAddr string) (*TCPTransport, error) {
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &TCPTransport{
+		LocalTransport: NewLocalTransport(),
+		listener:       ln,
+		clients:        make(map[string]*rpc.Client),
+	}
+
+	t.server = rpc.NewServer()
+	if err := t.server.RegisterName("Chord", &chordRPCService{t: t}); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	go t.acceptLoop()
+	return t, nil
+}
+
+// acceptLoop accepts inbound connections, backing off exponentially
+// (capped at acceptBackoffMax) after consecutive errors instead of
+// spinning the CPU on a wedged listener.
+func (t *TCPTransport) acceptLoop() {
+	var backoff acceptBackoff
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			delay := backoff.next()
+			nlog.Warnf("chord: accept error: %s, backing off %s", err, delay)
+			time.Sleep(delay)
+			continue
+		}
+		backoff.reset()
+		go t.server.ServeConn(conn)
+	}
+}
+
+// Close stops accepting new connections and drops cached client
+// connections.
+func (t *TCPTransport) Close() error {
+	err := t.listener.Close()
+
+	t.clientsMu.Lock()
+	for host, c := range t.clients {
+		c.Close()
+		delete(t.clients, host)
+	}
+	t.clientsMu.Unlock()
+
+	return err
+}
+
+func (t *TCPTransport) client(host string) (*rpc.Client, error) {
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+
+	if c, ok := t.clients[host]; ok {
+		return c, nil
+	}
+
+	addr, err := net.ResolveTCPAddr("tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	c, err := rpc.Dial("tcp", addr.String())
+	if err != nil {
+		return nil, err
+	}
+	t.clients[host] = c
+	return c, nil
+}
+
+func (t *TCPTransport) call(target *Vnode, args *InvokeArgs) (*InvokeReply, error) {
+	addr, err := target.NodeAddr()
+	if err != nil {
+		return nil, err
+	}
+	client, err := t.client(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &InvokeReply{}
+	if err := client.Call("Chord.Invoke", args, reply); err != nil {
+		// The cached connection is likely dead; drop it so the next
+		// call reconnects instead of failing forever.
+		t.clientsMu.Lock()
+		delete(t.clients, addr)
+		t.clientsMu.Unlock()
+		return nil, err
+	}
+	return reply, decodeErr(reply.ErrMsg)
+}
+
+func (t *TCPTransport) Ping(vn *Vnode) (bool, error) {
+	if o, ok := t.lookup(vn); ok {
+		return o.Ping()
+	}
+	reply, err := t.call(vn, &InvokeArgs{TargetId: vn.String(), Method: "Ping"})
+	if reply == nil {
+		return false, err
+	}
+	return reply.Ok, err
+}
+
+func (t *TCPTransport) GetPredecessor(vn *Vnode) (*Vnode, error) {
+	if o, ok := t.lookup(vn); ok {
+		return o.GetPredecessor(vn.Host)
+	}
+	reply, err := t.call(vn, &InvokeArgs{TargetId: vn.String(), Method: "GetPredecessor", Host: vn.Host})
+	if reply == nil {
+		return nil, err
+	}
+	return reply.Vn, err
+}
+
+func (t *TCPTransport) Notify(vn, self *Vnode, gossip []gossipMsg) ([]*Vnode, []gossipMsg, error) {
+	if o, ok := t.lookup(vn); ok {
+		return o.Notify(self.Host, self, gossip)
+	}
+	reply, err := t.call(vn, &InvokeArgs{TargetId: vn.String(), Method: "Notify", Host: self.Host, Vn: self, Gossip: gossip})
+	if reply == nil {
+		return nil, nil, err
+	}
+	return reply.Vnodes, reply.Gossip, err
+}
+
+func (t *TCPTransport) FindSuccessors(vn *Vnode, n int, key []byte) ([]*Vnode, error) {
+	if o, ok := t.lookup(vn); ok {
+		return o.FindSuccessors(vn.Host, n, key)
+	}
+	reply, err := t.call(vn, &InvokeArgs{TargetId: vn.String(), Method: "FindSuccessors", Host: vn.Host, N: n, Key: key})
+	if reply == nil {
+		return nil, err
+	}
+	return reply.Vnodes, err
+}
+
+func (t *TCPTransport) ClearPredecessor(target, self *Vnode) error {
+	if o, ok := t.lookup(target); ok {
+		return o.ClearPredecessor(self.Host, self)
+	}
+	_, err := t.call(target, &InvokeArgs{TargetId: target.String(), Method: "ClearPredecessor", Host: self.Host, Vn: self})
+	return err
+}
+
+func (t *TCPTransport) SkipSuccessor(target, self *Vnode) error {
+	if o, ok := t.lookup(target); ok {
+		return o.SkipSuccessor(self.Host, self)
+	}
+	_, err := t.call(target, &InvokeArgs{TargetId: target.String(), Method: "SkipSuccessor", Host: self.Host, Vn: self})
+	return err
+}
+
+func (t *TCPTransport) IndirectPing(via, target *Vnode) (bool, error) {
+	if o, ok := t.lookup(via); ok {
+		return o.IndirectPing(target)
+	}
+	reply, err := t.call(via, &InvokeArgs{TargetId: via.String(), Method: "IndirectPing", Vn: target})
+	if reply == nil {
+		return false, err
+	}
+	return reply.Ok, err
+}
+
+func (t *TCPTransport) RegisterTopic(target *Vnode, topic string, advertiser *Vnode, ttl time.Duration) error {
+	if o, ok := t.lookup(target); ok {
+		return o.RegisterTopic(topic, advertiser, ttl)
+	}
+	_, err := t.call(target, &InvokeArgs{TargetId: target.String(), Method: "RegisterTopic", Host: topic, Vn: advertiser, TTL: ttl})
+	return err
+}
+
+func (t *TCPTransport) TopicQuery(target *Vnode, topic string, limit int) ([]*Vnode, error) {
+	if o, ok := t.lookup(target); ok {
+		return o.TopicQuery(topic, limit)
+	}
+	reply, err := t.call(target, &InvokeArgs{TargetId: target.String(), Method: "TopicQuery", Host: topic, N: limit})
+	if reply == nil {
+		return nil, err
+	}
+	return reply.Vnodes, err
+}
+
+func (t *TCPTransport) ListVnodes(target *Vnode, remoteHost string) ([]*Vnode, error) {
+	if o, ok := t.lookup(target); ok {
+		return o.ListVnodes(remoteHost)
+	}
+	reply, err := t.call(target, &InvokeArgs{TargetId: target.String(), Method: "ListVnodes", Host: remoteHost})
+	if reply == nil {
+		return nil, err
+	}
+	return reply.Vnodes, err
+}
@@ -0,0 +1,136 @@
+package chord
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Token-bucket rate limiting on the chord RPC surface. FindSuccessors in
+// particular is cheap to request and expensive to serve (it recurses
+// into transport calls to closest-preceding nodes), so a single noisy
+// source can multiply its cost across the ring; bound it per-source and
+// globally before it ever reaches the concurrency limiter.
+const (
+	defaultSourceRefill   = 20.0 // tokens/sec for an unrecognized host
+	defaultSourceBurst    = 40.0
+	knownSourceRefill     = 40.0 // known neighbors get a larger bucket
+	knownSourceBurst      = 80.0
+	globalRefill          = 2000.0
+	globalBurst           = 4000.0
+	maxTrackedRateSources = 4096 // LRU cap so strangers can't grow memory unbounded
+)
+
+// ErrRateLimited is returned when a source has exhausted its token
+// bucket. It's distinguished from a transport-level failure so callers
+// like checkNewSuccessor/notifySuccessor back off instead of treating
+// the source as dead.
+var ErrRateLimited = errors.New("chord: rate limit exceeded")
+
+type tokenBucket struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	refill   float64
+	last     time.Time
+}
+
+func newTokenBucket(capacity, refill float64) *tokenBucket {
+	return &tokenBucket{tokens: capacity, capacity: capacity, refill: refill, last: time.Now()}
+}
+
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.refill
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// sourceBuckets is an LRU-capped map of per-host token buckets, so
+// tracking one-off strangers can't grow memory without bound.
+type sourceBuckets struct {
+	mu    sync.Mutex
+	cap   int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type sourceBucketEntry struct {
+	host   string
+	bucket *tokenBucket
+}
+
+func newSourceBuckets(capacity int) *sourceBuckets {
+	return &sourceBuckets{cap: capacity, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (s *sourceBuckets) get(host string, known bool) *tokenBucket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[host]; ok {
+		s.ll.MoveToFront(el)
+		return el.Value.(*sourceBucketEntry).bucket
+	}
+
+	burst, refill := defaultSourceBurst, defaultSourceRefill
+	if known {
+		burst, refill = knownSourceBurst, knownSourceRefill
+	}
+	entry := &sourceBucketEntry{host: host, bucket: newTokenBucket(burst, refill)}
+	el := s.ll.PushFront(entry)
+	s.items[host] = el
+
+	if s.ll.Len() > s.cap {
+		oldest := s.ll.Back()
+		if oldest != nil {
+			s.ll.Remove(oldest)
+			delete(s.items, oldest.Value.(*sourceBucketEntry).host)
+		}
+	}
+
+	return entry.bucket
+}
+
+// rpcRateLimiter is one localVnode's global bucket plus its per-source
+// LRU of buckets.
+type rpcRateLimiter struct {
+	global  *tokenBucket
+	sources *sourceBuckets
+}
+
+func (vn *localVnode) getRateLimiter() *rpcRateLimiter {
+	vn.rateLimiterMu.Lock()
+	defer vn.rateLimiterMu.Unlock()
+	if vn.rateLimiter == nil {
+		vn.rateLimiter = &rpcRateLimiter{
+			global:  newTokenBucket(globalBurst, globalRefill),
+			sources: newSourceBuckets(maxTrackedRateSources),
+		}
+	}
+	return vn.rateLimiter
+}
+
+// allowRPC charges one token against both the global bucket and
+// remoteHost's bucket, giving already-known neighbors (per
+// shouldConnectToHost) a larger allowance than strangers.
+func (vn *localVnode) allowRPC(remoteHost string) bool {
+	rl := vn.getRateLimiter()
+	if !rl.global.allow() {
+		return false
+	}
+	known := vn.shouldConnectToHost(remoteHost)
+	return rl.sources.get(remoteHost, known).allow()
+}
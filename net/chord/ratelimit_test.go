@@ -0,0 +1,43 @@
+package chord
+
+import "testing"
+
+func TestTokenBucketAllowsUpToCapacity(t *testing.T) {
+	b := newTokenBucket(3, 0)
+	for i := 0; i < 3; i++ {
+		if !b.allow() {
+			t.Fatalf("expected token %d of 3 to be allowed", i)
+		}
+	}
+	if b.allow() {
+		t.Fatal("expected bucket to be empty after capacity draws")
+	}
+}
+
+func TestTokenBucketRefillsOverTime(t *testing.T) {
+	b := newTokenBucket(1, 100)
+	if !b.allow() {
+		t.Fatal("expected the initial token to be available")
+	}
+	if b.allow() {
+		t.Fatal("expected the bucket to be empty immediately after draining it")
+	}
+
+	// Simulate the passage of time without sleeping: back-date last so
+	// the next allow() call sees enough elapsed time to refill.
+	b.last = b.last.Add(-50000000) // 50ms in nanoseconds
+	if !b.allow() {
+		t.Fatal("expected a token to have refilled after the simulated delay")
+	}
+}
+
+func TestSourceBucketsEvictsLRU(t *testing.T) {
+	sb := newSourceBuckets(2)
+	a := sb.get("a", false)
+	sb.get("b", false)
+	sb.get("c", false) // should evict "a", the least recently used
+
+	if sb.get("a", false) == a {
+		t.Fatal("expected \"a\"'s bucket to have been evicted and recreated")
+	}
+}
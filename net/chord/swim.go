@@ -0,0 +1,248 @@
+package chord
+
+import (
+	"math/rand"
+	"sync"
+
+	nlog "github.com/nknorg/nkn/util/log"
+)
+
+// SWIM-style failure detection tunables. See swimProbe for how these are
+// used: one direct probe per stabilize tick, falling back to indirect
+// probes through a handful of other neighbors before a node is even
+// considered suspect.
+const (
+	defaultIndirectChecks   = 3
+	defaultSuspicionRounds  = 4
+	defaultGossipRetransmit = 4
+)
+
+// gossipMsg piggybacks suspicion/alive information on Notify traffic so
+// it spreads through the ring without dedicated RPCs.
+type gossipMsg struct {
+	Target  *Vnode
+	Suspect bool
+}
+
+type suspectEntry struct {
+	vn     *Vnode
+	rounds int
+}
+
+type gossipEntry struct {
+	msg       gossipMsg
+	remaining int
+}
+
+// swimState holds the suspicion table and outbound gossip buffer for one
+// localVnode. It's lazily allocated into localVnode.swim on first use by
+// swimTable.
+type swimState struct {
+	mu       sync.Mutex
+	suspects map[string]*suspectEntry
+	outbox   []*gossipEntry
+}
+
+func (vn *localVnode) swimTable() *swimState {
+	vn.swimMu.Lock()
+	defer vn.swimMu.Unlock()
+	if vn.swim == nil {
+		vn.swim = &swimState{suspects: make(map[string]*suspectEntry)}
+	}
+	return vn.swim
+}
+
+// swimProbe runs one round of SWIM-style failure detection: a single
+// direct ping, falling back to k indirect pings via random neighbors,
+// before the target is marked suspect. This replaces pinging every
+// neighbor every tick.
+func (vn *localVnode) swimProbe() {
+	neighbors := vn.GetNeighbors()
+	if len(neighbors) == 0 {
+		return
+	}
+
+	target := neighbors[rand.Intn(len(neighbors))]
+	trans := vn.ring.transport
+
+	if alive, err := trans.Ping(target); err == nil && alive {
+		vn.refute(target)
+		return
+	}
+
+	helpers := pickRandomDistinct(neighbors, target, defaultIndirectChecks)
+	if len(helpers) == 0 {
+		vn.markSuspect(target)
+		return
+	}
+
+	results := make(chan bool, len(helpers))
+	for _, h := range helpers {
+		h := h
+		go func() {
+			ok, err := trans.IndirectPing(h, target)
+			results <- err == nil && ok
+		}()
+	}
+
+	for i := 0; i < len(helpers); i++ {
+		if <-results {
+			vn.refute(target)
+			return
+		}
+	}
+
+	vn.markSuspect(target)
+}
+
+func pickRandomDistinct(neighbors []*Vnode, exclude *Vnode, k int) []*Vnode {
+	candidates := make([]*Vnode, 0, len(neighbors))
+	for _, n := range neighbors {
+		if n.String() != exclude.String() {
+			candidates = append(candidates, n)
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if k > len(candidates) {
+		k = len(candidates)
+	}
+	return candidates[:k]
+}
+
+// markSuspect starts (or continues) the suspicion timer for target. Once
+// a node has been suspect for defaultSuspicionRounds consecutive
+// stabilize rounds without being refuted, it's declared dead.
+func (vn *localVnode) markSuspect(target *Vnode) {
+	s := vn.swimTable()
+	s.mu.Lock()
+	key := target.String()
+	if _, ok := s.suspects[key]; !ok {
+		nlog.Warnf("Suspecting %s at %s of being dead", key, target.Host)
+		s.suspects[key] = &suspectEntry{vn: target, rounds: defaultSuspicionRounds}
+	}
+	s.mu.Unlock()
+
+	vn.queueGossip(gossipMsg{Target: target, Suspect: true})
+}
+
+// refute clears any suspicion of target and gossips that it's alive.
+func (vn *localVnode) refute(target *Vnode) {
+	s := vn.swimTable()
+	s.mu.Lock()
+	_, wasSuspect := s.suspects[target.String()]
+	delete(s.suspects, target.String())
+	s.mu.Unlock()
+
+	if wasSuspect {
+		vn.queueGossip(gossipMsg{Target: target, Suspect: false})
+	}
+}
+
+// tickSuspicions ages every open suspicion by one stabilize round,
+// declaring dead (and evicting) anything that's run out of rounds
+// without being refuted.
+func (vn *localVnode) tickSuspicions() {
+	s := vn.swimTable()
+	s.mu.Lock()
+	var dead []*Vnode
+	for key, entry := range s.suspects {
+		entry.rounds--
+		if entry.rounds <= 0 {
+			dead = append(dead, entry.vn)
+			delete(s.suspects, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, d := range dead {
+		nlog.Warnf("Declaring %s at %s dead after exhausting suspicion rounds", d.String(), d.Host)
+		vn.removeNeighbor(d)
+	}
+}
+
+// queueGossip schedules msg to be piggybacked on the next
+// defaultGossipRetransmit outbound Notify calls.
+func (vn *localVnode) queueGossip(msg gossipMsg) {
+	s := vn.swimTable()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outbox = append(s.outbox, &gossipEntry{msg: msg, remaining: defaultGossipRetransmit})
+}
+
+// pendingGossip returns the outbound gossip buffer, to be attached to the
+// next Notify RPC; each entry stays buffered until it has been returned
+// defaultGossipRetransmit times. Alive/refutation gossip is prioritized ahead of plain
+// suspicion so refutations spread at least as fast as the suspicion they
+// cancel.
+func (vn *localVnode) pendingGossip() []gossipMsg {
+	s := vn.swimTable()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sort := func(a, b *gossipEntry) bool { return !a.msg.Suspect && b.msg.Suspect }
+	for i := 1; i < len(s.outbox); i++ {
+		for j := i; j > 0 && sort(s.outbox[j], s.outbox[j-1]); j-- {
+			s.outbox[j], s.outbox[j-1] = s.outbox[j-1], s.outbox[j]
+		}
+	}
+
+	msgs := make([]gossipMsg, 0, len(s.outbox))
+	kept := s.outbox[:0]
+	for _, g := range s.outbox {
+		msgs = append(msgs, g.msg)
+		g.remaining--
+		if g.remaining > 0 {
+			kept = append(kept, g)
+		}
+	}
+	s.outbox = kept
+	return msgs
+}
+
+// mergeGossip applies suspicion/alive gossip received from a peer,
+// letting suspicion (and refutation) of a shared neighbor spread through
+// the ring without every vnode having to observe the failure itself.
+func (vn *localVnode) mergeGossip(msgs []gossipMsg) {
+	for _, g := range msgs {
+		if g.Target == nil || g.Target.String() == vn.String() {
+			continue
+		}
+		if g.Suspect {
+			vn.markSuspect(g.Target)
+		} else {
+			vn.refute(g.Target)
+		}
+	}
+}
+
+// removeNeighbor evicts a confirmed-dead node from the predecessor slot,
+// successor list and finger table, mirroring the ad-hoc removal logic
+// that checkNewSuccessor/checkPredecessor still apply inline.
+func (vn *localVnode) removeNeighbor(target *Vnode) {
+	conf := vn.ring.config
+
+	if vn.predecessor != nil && vn.predecessor.String() == target.String() {
+		old := vn.predecessor
+		vn.ring.invokeDelegate(func() {
+			conf.Delegate.PredecessorLeaving(&vn.Vnode, old)
+		})
+		vn.predecessor = nil
+	}
+
+	for i, s := range vn.successors {
+		if s != nil && s.String() == target.String() {
+			known := vn.knownSuccessors()
+			copy(vn.successors[i:], vn.successors[i+1:])
+			vn.successors[known-1] = nil
+			break
+		}
+	}
+
+	for i, f := range vn.finger {
+		if f != nil && f.String() == target.String() {
+			vn.finger[i] = nil
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package chord
+
+import "testing"
+
+// TestPendingGossipPrioritizesRefutations checks that alive/refutation
+// gossip is drained ahead of plain suspicion gossip, so a refutation
+// spreads at least as fast as the suspicion it's meant to cancel.
+func TestPendingGossipPrioritizesRefutations(t *testing.T) {
+	vn := &localVnode{}
+
+	vn.queueGossip(gossipMsg{Target: &Vnode{Id: []byte{1}}, Suspect: true})
+	vn.queueGossip(gossipMsg{Target: &Vnode{Id: []byte{2}}, Suspect: true})
+	vn.queueGossip(gossipMsg{Target: &Vnode{Id: []byte{3}}, Suspect: false})
+
+	msgs := vn.pendingGossip()
+	if len(msgs) != 3 {
+		t.Fatalf("expected all 3 queued messages, got %d", len(msgs))
+	}
+	if msgs[0].Suspect {
+		t.Fatalf("expected the refutation to be drained first, got %+v", msgs[0])
+	}
+}
+
+// TestPendingGossipRetransmitLimit checks that a gossip entry stops being
+// returned once it's been drained defaultGossipRetransmit times.
+func TestPendingGossipRetransmitLimit(t *testing.T) {
+	vn := &localVnode{}
+	vn.queueGossip(gossipMsg{Target: &Vnode{Id: []byte{1}}, Suspect: true})
+
+	for i := 0; i < defaultGossipRetransmit; i++ {
+		if msgs := vn.pendingGossip(); len(msgs) != 1 {
+			t.Fatalf("round %d: expected the message to still be pending, got %d messages", i, len(msgs))
+		}
+	}
+	if msgs := vn.pendingGossip(); len(msgs) != 0 {
+		t.Fatalf("expected the message to stop being retransmitted after %d rounds, got %d", defaultGossipRetransmit, len(msgs))
+	}
+}
@@ -0,0 +1,288 @@
+package chord
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	nlog "github.com/nknorg/nkn/util/log"
+)
+
+// maxTopicQueueSize bounds the number of live advertisements a vnode will
+// keep for a single topic. Once full, the oldest advertisement is evicted
+// to make room for the newest one (FIFO).
+const maxTopicQueueSize = 200
+
+// maxTrackedTopics bounds the number of distinct topics a vnode will
+// track advertisements for, LRU-evicting the least recently used topic
+// once full - same pattern as sourceBuckets in ratelimit.go, since
+// otherwise a remote peer could grow topicTable.queues without bound
+// just by calling RegisterTopic with unique topic names.
+const maxTrackedTopics = 4096
+
+// Advertisement bounds for the re-advertise loop started by Ring.Advertise.
+const (
+	minAdvertiseInterval = 5 * time.Second
+	maxAdvertiseInterval = 2 * time.Minute
+)
+
+// topicAd is a single live advertisement: some Vnode claims to serve
+// `topic` until Expire.
+type topicAd struct {
+	Vn     *Vnode
+	Expire time.Time
+}
+
+// topicQueue is the FIFO of advertisements a vnode holds for one topic.
+type topicQueue struct {
+	mu      sync.Mutex
+	entries []*topicAd
+}
+
+func (q *topicQueue) register(vn *Vnode, ttl time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	expire := time.Now().Add(ttl)
+	for _, ad := range q.entries {
+		if ad.Vn.String() == vn.String() {
+			ad.Vn = vn
+			ad.Expire = expire
+			return
+		}
+	}
+
+	if len(q.entries) >= maxTopicQueueSize {
+		// Evict the oldest entry to make room.
+		q.entries = q.entries[1:]
+	}
+	q.entries = append(q.entries, &topicAd{Vn: vn, Expire: expire})
+}
+
+// live returns up to limit non-expired advertisements, pruning expired
+// entries as it goes.
+func (q *topicQueue) live(limit int) []*Vnode {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	fresh := q.entries[:0]
+	for _, ad := range q.entries {
+		if ad.Expire.After(now) {
+			fresh = append(fresh, ad)
+		}
+	}
+	q.entries = fresh
+
+	if limit <= 0 || limit > len(q.entries) {
+		limit = len(q.entries)
+	}
+	out := make([]*Vnode, limit)
+	for i := 0; i < limit; i++ {
+		out[i] = q.entries[i].Vn
+	}
+	return out
+}
+
+// topicTable is the set of topics a single localVnode is tracking
+// advertisements for, LRU-capped at maxTrackedTopics so a remote peer
+// can't grow it without bound just by registering unique topic names -
+// same list+map pattern as sourceBuckets in ratelimit.go.
+type topicTable struct {
+	mu     sync.Mutex
+	ll     *list.List
+	queues map[string]*list.Element
+}
+
+type topicEntry struct {
+	topic string
+	queue *topicQueue
+}
+
+func (t *topicTable) queueFor(topic string) *topicQueue {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if el, ok := t.queues[topic]; ok {
+		t.ll.MoveToFront(el)
+		return el.Value.(*topicEntry).queue
+	}
+
+	entry := &topicEntry{topic: topic, queue: &topicQueue{}}
+	el := t.ll.PushFront(entry)
+	t.queues[topic] = el
+
+	if t.ll.Len() > maxTrackedTopics {
+		oldest := t.ll.Back()
+		if oldest != nil {
+			t.ll.Remove(oldest)
+			delete(t.queues, oldest.Value.(*topicEntry).topic)
+		}
+	}
+
+	return entry.queue
+}
+
+// topicsTable lazily initializes vn.topics on first use. It's a field on
+// localVnode rather than a package-level registry, so it's reclaimed for
+// free whenever the localVnode itself is.
+func (vn *localVnode) topicsTable() *topicTable {
+	vn.topicsMu.Lock()
+	defer vn.topicsMu.Unlock()
+	if vn.topics == nil {
+		vn.topics = &topicTable{ll: list.New(), queues: make(map[string]*list.Element)}
+	}
+	return vn.topics
+}
+
+// RPC: RegisterTopic is invoked by a remote advertiser to claim that it
+// serves `topic` for the next `ttl`.
+func (vn *localVnode) RegisterTopic(topic string, advertiser *Vnode, ttl time.Duration) error {
+	if !vn.allowRPC(advertiser.Host) {
+		return ErrRateLimited
+	}
+	if !vn.getLimiter().tryAcquire() {
+		return ErrBusy
+	}
+	defer vn.getLimiter().release()
+
+	vn.topicsTable().queueFor(topic).register(advertiser, ttl)
+	return nil
+}
+
+// RPC: TopicQuery returns up to limit live advertisers for topic known to
+// this vnode. There's no remoteHost to charge against a per-source rate
+// limiter here (the wire envelope doesn't carry one for this RPC), but
+// it still shares the inbound concurrency limiter with every other RPC
+// handler except Ping and IndirectPing, and topicsTable()'s LRU cap keeps
+// the query itself cheap.
+func (vn *localVnode) TopicQuery(topic string, limit int) ([]*Vnode, error) {
+	if !vn.getLimiter().tryAcquire() {
+		return nil, ErrBusy
+	}
+	defer vn.getLimiter().release()
+
+	return vn.topicsTable().queueFor(topic).live(limit), nil
+}
+
+// topicHash hashes a topic name into the same ID space as vnodes, so
+// "the topic ring" is just the successor list of hash(topic).
+func (r *Ring) topicHash(topic string) []byte {
+	h := r.config.HashFunc()
+	h.Write([]byte(topic))
+	return h.Sum(nil)
+}
+
+// Advertise claims that this ring serves `topic` for ttl at a time,
+// re-advertising on the topic ring's successors until stop is called.
+// The re-advertise interval adapts to observed registration latency (see
+// nextAdvertiseInterval). Every round registers at the same
+// NumSuccessors successors of hash(topic); the set of nodes holding the
+// registrations does not grow for popular (slow to register) topics.
+func (r *Ring) Advertise(topic string, ttl time.Duration) (stop func()) {
+	local := r.vnodes[0]
+	stopCh := make(chan struct{})
+
+	go func() {
+		interval := ttl / 3
+		if interval < minAdvertiseInterval {
+			interval = minAdvertiseInterval
+		}
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-timer.C:
+				start := time.Now()
+				if err := r.registerOnTopicRing(local, topic, ttl); err != nil {
+					nlog.Warnf("Advertise(%s): %s", topic, err)
+				}
+				interval = nextAdvertiseInterval(interval, time.Since(start))
+				timer.Reset(interval)
+			}
+		}
+	}()
+
+	return func() { close(stopCh) }
+}
+
+// nextAdvertiseInterval grows the re-advertise interval when registering
+// is slow (the topic ring is crowded / far away) and decays it back down
+// otherwise, clamped to [minAdvertiseInterval, maxAdvertiseInterval].
+func nextAdvertiseInterval(current, observed time.Duration) time.Duration {
+	next := current
+	if observed > current/4 {
+		next = current * 2
+	} else {
+		next = current - current/4
+	}
+	if next < minAdvertiseInterval {
+		next = minAdvertiseInterval
+	}
+	if next > maxAdvertiseInterval {
+		next = maxAdvertiseInterval
+	}
+	return next
+}
+
+func (r *Ring) registerOnTopicRing(local *localVnode, topic string, ttl time.Duration) error {
+	key := r.topicHash(topic)
+	targets, err := local.FindSuccessors(local.Host, r.config.NumSuccessors, key)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, target := range targets {
+		if target == nil {
+			continue
+		}
+		if err := r.transport.RegisterTopic(target, topic, &local.Vnode, ttl); err != nil {
+			lastErr = err
+			nlog.Warnf("RegisterTopic(%s) on %s failed: %s", topic, target.String(), err)
+		}
+	}
+	return lastErr
+}
+
+// LookupTopic returns up to n live Vnodes advertising topic, walking
+// further along the topic ring's successor chain if the first node
+// queried doesn't have enough.
+func (r *Ring) LookupTopic(topic string, n int) ([]*Vnode, error) {
+	local := r.vnodes[0]
+	key := r.topicHash(topic)
+
+	targets, err := local.FindSuccessors(local.Host, r.config.NumSuccessors, key)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]*Vnode, 0, n)
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		if target == nil || len(found) >= n {
+			continue
+		}
+		ads, err := r.transport.TopicQuery(target, topic, n-len(found))
+		if err != nil {
+			nlog.Warnf("TopicQuery(%s) on %s failed: %s", topic, target.String(), err)
+			continue
+		}
+		for _, ad := range ads {
+			if ad == nil || seen[ad.String()] {
+				continue
+			}
+			seen[ad.String()] = true
+			found = append(found, ad)
+			if len(found) >= n {
+				break
+			}
+		}
+	}
+
+	return found, nil
+}
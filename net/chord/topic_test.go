@@ -0,0 +1,72 @@
+package chord
+
+import (
+	"testing"
+	"time"
+)
+
+// testVnode returns a Vnode distinguishable by Id (what Vnode.String(),
+// and therefore topicQueue's dedup-by-registrant check, key off of) while
+// keeping Host around for human-readable assertions.
+func testVnode(id byte, host string) *Vnode {
+	return &Vnode{Id: []byte{id}, Host: host}
+}
+
+func TestTopicQueueLiveDropsExpired(t *testing.T) {
+	q := &topicQueue{}
+	q.register(testVnode(1, "alive"), time.Minute)
+	q.register(testVnode(2, "expired"), -time.Second)
+
+	live := q.live(0)
+	if len(live) != 1 || live[0].Host != "alive" {
+		t.Fatalf("expected only the unexpired entry, got %v", live)
+	}
+	if len(q.entries) != 1 {
+		t.Fatalf("expected live() to prune the expired entry, %d entries remain", len(q.entries))
+	}
+}
+
+func TestTopicQueueLiveRespectsLimit(t *testing.T) {
+	q := &topicQueue{}
+	for i := 0; i < 5; i++ {
+		q.register(testVnode(byte(i), string(rune('a'+i))), time.Minute)
+	}
+
+	live := q.live(2)
+	if len(live) != 2 {
+		t.Fatalf("expected limit to cap the result at 2, got %d", len(live))
+	}
+}
+
+func TestTopicQueueRegisterEvictsOldestWhenFull(t *testing.T) {
+	q := &topicQueue{}
+	for i := 0; i < maxTopicQueueSize; i++ {
+		q.register(testVnode(byte(i%256), "filler"), time.Minute)
+	}
+	q.register(testVnode(255, "newest"), time.Minute)
+
+	if len(q.entries) != maxTopicQueueSize {
+		t.Fatalf("expected queue to stay capped at %d, got %d", maxTopicQueueSize, len(q.entries))
+	}
+	last := q.entries[len(q.entries)-1]
+	if last.Vn.Host != "newest" {
+		t.Fatalf("expected newest registration to survive eviction, got %q", last.Vn.Host)
+	}
+}
+
+func TestNextAdvertiseInterval(t *testing.T) {
+	base := 30 * time.Second
+
+	if got := nextAdvertiseInterval(base, base); got <= base {
+		t.Fatalf("slow registration should grow the interval, got %s from base %s", got, base)
+	}
+	if got := nextAdvertiseInterval(base, time.Millisecond); got >= base {
+		t.Fatalf("fast registration should shrink the interval, got %s from base %s", got, base)
+	}
+	if got := nextAdvertiseInterval(minAdvertiseInterval, time.Millisecond); got < minAdvertiseInterval {
+		t.Fatalf("interval must not shrink below minAdvertiseInterval, got %s", got)
+	}
+	if got := nextAdvertiseInterval(maxAdvertiseInterval, maxAdvertiseInterval); got > maxAdvertiseInterval {
+		t.Fatalf("interval must not grow past maxAdvertiseInterval, got %s", got)
+	}
+}
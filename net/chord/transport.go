@@ -0,0 +1,159 @@
+package chord
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrUnknownVnode is returned by a transport when asked to dispatch an
+// RPC to a Vnode it has no registration for.
+var ErrUnknownVnode = errors.New("chord: unknown vnode")
+
+// VnodeRPC is the set of RPC handlers a registered Vnode must answer.
+// localVnode implements all of these; Transport implementations decode
+// an inbound request off the wire and call straight through to them.
+type VnodeRPC interface {
+	Ping() (bool, error)
+	GetPredecessor(remoteHost string) (*Vnode, error)
+	Notify(remoteHost string, self *Vnode, gossip []gossipMsg) ([]*Vnode, []gossipMsg, error)
+	FindSuccessors(remoteHost string, n int, key []byte) ([]*Vnode, error)
+	ClearPredecessor(remoteHost string, p *Vnode) error
+	SkipSuccessor(remoteHost string, s *Vnode) error
+	IndirectPing(target *Vnode) (bool, error)
+	RegisterTopic(topic string, advertiser *Vnode, ttl time.Duration) error
+	TopicQuery(topic string, limit int) ([]*Vnode, error)
+	ListVnodes(remoteHost string) ([]*Vnode, error)
+}
+
+// Transport is everything a Vnode needs to reach the rest of the ring.
+// Every method here corresponds one-to-one with a VnodeRPC handler,
+// except Register, which is purely local bookkeeping.
+type Transport interface {
+	Register(vn *Vnode, o VnodeRPC)
+
+	Ping(vn *Vnode) (bool, error)
+	GetPredecessor(vn *Vnode) (*Vnode, error)
+	Notify(vn, self *Vnode, gossip []gossipMsg) ([]*Vnode, []gossipMsg, error)
+	FindSuccessors(vn *Vnode, n int, key []byte) ([]*Vnode, error)
+	ClearPredecessor(target, self *Vnode) error
+	SkipSuccessor(target, self *Vnode) error
+	IndirectPing(via, target *Vnode) (bool, error)
+	RegisterTopic(target *Vnode, topic string, advertiser *Vnode, ttl time.Duration) error
+	TopicQuery(target *Vnode, topic string, limit int) ([]*Vnode, error)
+	ListVnodes(target *Vnode, remoteHost string) ([]*Vnode, error)
+}
+
+// LocalTransport dispatches directly to in-process Vnodes, keyed by
+// Vnode.String(). It's both the registry every other Transport
+// implementation (e.g. TCPTransport) consults for locally-hosted
+// Vnodes, and a complete Transport on its own for same-process rings
+// (tests, single-node bootstraps).
+type LocalTransport struct {
+	mu    sync.RWMutex
+	local map[string]VnodeRPC
+}
+
+func NewLocalTransport() *LocalTransport {
+	return &LocalTransport{local: make(map[string]VnodeRPC)}
+}
+
+func (t *LocalTransport) Register(vn *Vnode, o VnodeRPC) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.local[vn.String()] = o
+}
+
+func (t *LocalTransport) lookup(vn *Vnode) (VnodeRPC, bool) {
+	return t.lookupByID(vn.String())
+}
+
+// lookupByID looks up a locally-registered Vnode by the same string key
+// Vnode.String() produces, for callers (e.g. the wire dispatcher in
+// net_transport.go) that only have the id off the wire, not a *Vnode.
+func (t *LocalTransport) lookupByID(id string) (VnodeRPC, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	o, ok := t.local[id]
+	return o, ok
+}
+
+func (t *LocalTransport) Ping(vn *Vnode) (bool, error) {
+	o, ok := t.lookup(vn)
+	if !ok {
+		return false, ErrUnknownVnode
+	}
+	return o.Ping()
+}
+
+func (t *LocalTransport) GetPredecessor(vn *Vnode) (*Vnode, error) {
+	o, ok := t.lookup(vn)
+	if !ok {
+		return nil, ErrUnknownVnode
+	}
+	return o.GetPredecessor(vn.Host)
+}
+
+func (t *LocalTransport) Notify(vn, self *Vnode, gossip []gossipMsg) ([]*Vnode, []gossipMsg, error) {
+	o, ok := t.lookup(vn)
+	if !ok {
+		return nil, nil, ErrUnknownVnode
+	}
+	return o.Notify(self.Host, self, gossip)
+}
+
+func (t *LocalTransport) FindSuccessors(vn *Vnode, n int, key []byte) ([]*Vnode, error) {
+	o, ok := t.lookup(vn)
+	if !ok {
+		return nil, ErrUnknownVnode
+	}
+	return o.FindSuccessors(vn.Host, n, key)
+}
+
+func (t *LocalTransport) ClearPredecessor(target, self *Vnode) error {
+	o, ok := t.lookup(target)
+	if !ok {
+		return ErrUnknownVnode
+	}
+	return o.ClearPredecessor(self.Host, self)
+}
+
+func (t *LocalTransport) SkipSuccessor(target, self *Vnode) error {
+	o, ok := t.lookup(target)
+	if !ok {
+		return ErrUnknownVnode
+	}
+	return o.SkipSuccessor(self.Host, self)
+}
+
+func (t *LocalTransport) IndirectPing(via, target *Vnode) (bool, error) {
+	o, ok := t.lookup(via)
+	if !ok {
+		return false, ErrUnknownVnode
+	}
+	return o.IndirectPing(target)
+}
+
+func (t *LocalTransport) RegisterTopic(target *Vnode, topic string, advertiser *Vnode, ttl time.Duration) error {
+	o, ok := t.lookup(target)
+	if !ok {
+		return ErrUnknownVnode
+	}
+	return o.RegisterTopic(topic, advertiser, ttl)
+}
+
+func (t *LocalTransport) TopicQuery(target *Vnode, topic string, limit int) ([]*Vnode, error) {
+	o, ok := t.lookup(target)
+	if !ok {
+		return nil, ErrUnknownVnode
+	}
+	return o.TopicQuery(topic, limit)
+}
+
+func (t *LocalTransport) ListVnodes(target *Vnode, remoteHost string) ([]*Vnode, error) {
+	o, ok := t.lookup(target)
+	if !ok {
+		return nil, ErrUnknownVnode
+	}
+	return o.ListVnodes(remoteHost)
+}
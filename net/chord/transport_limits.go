@@ -0,0 +1,110 @@
+package chord
+
+import (
+	"errors"
+	"time"
+)
+
+// defaultInboundConcurrency bounds how many stabilize-class RPCs
+// (GetPredecessor, Notify, FindSuccessors, ClearPredecessor,
+// SkipSuccessor) a single localVnode will service at once. Beyond that,
+// callers get ErrBusy instead of queuing up behind a slow neighbor.
+const defaultInboundConcurrency = 128
+
+// fastRetryInterval is how soon a caller that hit ErrBusy retries,
+// instead of waiting out a full stabilize interval.
+const fastRetryInterval = 250 * time.Millisecond
+
+// ErrBusy is returned by an inbound RPC handler that's over its
+// concurrency limit. Callers should back off and retry rather than
+// treating it like a dead peer.
+var ErrBusy = errors.New("chord: vnode busy, retry later")
+
+// inboundLimiter is a non-blocking semaphore: tryAcquire fails fast
+// instead of making the remote caller wait.
+type inboundLimiter struct {
+	sem chan struct{}
+}
+
+func newInboundLimiter(n int) *inboundLimiter {
+	if n <= 0 {
+		n = defaultInboundConcurrency
+	}
+	return &inboundLimiter{sem: make(chan struct{}, n)}
+}
+
+func (l *inboundLimiter) tryAcquire() bool {
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *inboundLimiter) release() {
+	<-l.sem
+}
+
+func (vn *localVnode) getLimiter() *inboundLimiter {
+	vn.limiterMu.Lock()
+	defer vn.limiterMu.Unlock()
+	if vn.limiter == nil {
+		n := defaultInboundConcurrency
+		if vn.ring != nil && vn.ring.config.InboundConcurrency > 0 {
+			n = vn.ring.config.InboundConcurrency
+		}
+		vn.limiter = newInboundLimiter(n)
+	}
+	return vn.limiter
+}
+
+// requestFastRetry/consumeFastRetry back the fastRetry flag consulted by
+// schedule() to shorten the next stabilize tick after an ErrBusy
+// response, rather than waiting out the normal stabilize interval.
+func (vn *localVnode) requestFastRetry() {
+	vn.fastRetryMu.Lock()
+	vn.fastRetry = true
+	vn.fastRetryMu.Unlock()
+}
+
+func (vn *localVnode) consumeFastRetry() bool {
+	vn.fastRetryMu.Lock()
+	defer vn.fastRetryMu.Unlock()
+	if vn.fastRetry {
+		vn.fastRetry = false
+		return true
+	}
+	return false
+}
+
+// acceptBackoff implements the exponential accept-loop backoff used by
+// the transport after consecutive Accept errors, capped so a noisy
+// listener never sleeps for long.
+type acceptBackoff struct {
+	delay time.Duration
+}
+
+const (
+	acceptBackoffMin = 5 * time.Millisecond
+	acceptBackoffMax = time.Second
+)
+
+// next returns how long to sleep after another Accept error, doubling
+// the previous delay up to acceptBackoffMax.
+func (b *acceptBackoff) next() time.Duration {
+	if b.delay == 0 {
+		b.delay = acceptBackoffMin
+	} else {
+		b.delay *= 2
+		if b.delay > acceptBackoffMax {
+			b.delay = acceptBackoffMax
+		}
+	}
+	return b.delay
+}
+
+// reset clears the backoff after a successful Accept.
+func (b *acceptBackoff) reset() {
+	b.delay = 0
+}
@@ -0,0 +1,126 @@
+package chord
+
+import (
+	"hash"
+	"sync"
+	"time"
+)
+
+// Vnode represents a single virtual node location on the ring. Id is the
+// hashed position; Incarnation is bumped every time a host restarts and
+// reclaims the same (deterministic) Id, so neighbors can tell a fresh
+// instance apart from the stale one it replaced.
+type Vnode struct {
+	Id          []byte
+	Host        string
+	NodePort    uint16
+	HttpWsPort  uint16
+	Incarnation uint64
+}
+
+// VnodeData is the wire/marshalable form of a Vnode.
+type VnodeData struct {
+	Id         string
+	Host       string
+	NodePort   uint16
+	HttpWsPort uint16
+}
+
+// localVnodeData is the wire/marshalable form of a localVnode's full
+// neighborhood, as produced by localVnode.toData().
+type localVnodeData struct {
+	VnodeData
+	Successors  []*VnodeData
+	Finger      []*VnodeData
+	Predecessor *VnodeData
+	LastFinger  int
+}
+
+// localVnode is a Vnode hosted by this process. Subsystem-specific state
+// (topic advertisements, SWIM suspicion, inbound concurrency/rate
+// limiting) lives as fields here rather than in package-level maps keyed
+// by pointer, so it's torn down for free when the localVnode itself is
+// garbage collected instead of leaking for the life of the process.
+type localVnode struct {
+	Vnode
+	ring        *Ring
+	successors  []*Vnode
+	finger      []*Vnode
+	last_finger int
+	predecessor *Vnode
+	stabilized  time.Time
+	timer       *time.Timer
+
+	// OnNewSuccessor, if set, is invoked whenever checkNewSuccessor
+	// installs a new immediate successor.
+	OnNewSuccessor func()
+
+	topicsMu sync.Mutex
+	topics   *topicTable
+
+	swimMu sync.Mutex
+	swim   *swimState
+
+	limiterMu sync.Mutex
+	limiter   *inboundLimiter
+
+	fastRetryMu sync.Mutex
+	fastRetry   bool
+
+	rateLimiterMu sync.Mutex
+	rateLimiter   *rpcRateLimiter
+}
+
+// Delegate receives ring membership events for a Vnode.
+type Delegate interface {
+	NewPredecessor(local, remoteNew, remotePrev *Vnode)
+	Leaving(local, pred, succ *Vnode)
+	PredecessorLeaving(local, pred *Vnode)
+	SuccessorLeaving(local, succ *Vnode)
+}
+
+// Config holds the tunables for a Ring.
+type Config struct {
+	Hostname      string
+	NumSuccessors int
+	HashFunc      func() hash.Hash
+	StabilizeMin  time.Duration
+	StabilizeMax  time.Duration
+	JoinBlkHeight uint32
+	Delegate      Delegate
+	hashBits      int
+
+	// InboundConcurrency bounds concurrent stabilize-class RPCs per
+	// vnode; 0 uses defaultInboundConcurrency.
+	InboundConcurrency int
+
+	// IncarnationStore persists incarnation numbers across restarts;
+	// nil falls back to a process-lifetime-only store.
+	IncarnationStore IncarnationStore
+}
+
+// Ring is a chord ring hosted by this process, potentially as several
+// local Vnodes.
+type Ring struct {
+	config     *Config
+	transport  Transport
+	vnodes     []*localVnode
+	delegateCh chan func()
+	shutdown   chan bool
+
+	listVnodesACLMu sync.Mutex
+	listVnodesACL   ListVnodesACL
+}
+
+// invokeDelegate queues f to run on the delegate goroutine, so Transport
+// RPC handlers never block on user delegate code. If delegateCh is full,
+// f is dropped.
+func (r *Ring) invokeDelegate(f func()) {
+	if r.config.Delegate == nil || r.delegateCh == nil {
+		return
+	}
+	select {
+	case r.delegateCh <- f:
+	default:
+	}
+}
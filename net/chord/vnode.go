@@ -53,6 +53,10 @@ func (vn *localVnode) init(idx int) {
 	// Generate an ID
 	vn.genId(ringCfg.Hostname, ringCfg.JoinBlkHeight+uint32(idx))
 
+	// Bump our incarnation so neighbors prefer us over any stale entries
+	// left behind by a previous run with the same Id.
+	vn.initIncarnation()
+
 	// Set our host
 	vn.Host = ringCfg.Hostname
 
@@ -66,16 +70,19 @@ func (vn *localVnode) init(idx int) {
 
 	// Register with the RPC mechanism
 	vn.ring.transport.Register(&vn.Vnode, vn)
-
-	if idx == 0 {
-		go vn.keepalive()
-	}
 }
 
 // Schedules the Vnode to do regular maintenence
 func (vn *localVnode) schedule() {
+	// A neighbor that answered ErrBusy gets retried sooner than the next
+	// regularly scheduled stabilize tick.
+	delay := randStabilize(vn.ring.config)
+	if vn.consumeFastRetry() {
+		delay = fastRetryInterval
+	}
+
 	// Setup our stabilize timer
-	vn.timer = time.AfterFunc(randStabilize(vn.ring.config), vn.stabilize)
+	vn.timer = time.AfterFunc(delay, vn.stabilize)
 }
 
 // Generates an ID for the node
@@ -105,6 +112,12 @@ func (vn *localVnode) stabilize() {
 	// Setup the next stabilize timer
 	defer vn.schedule()
 
+	// Run one round of SWIM-style failure detection: a single direct
+	// probe, with indirect probes and a suspicion timer standing in for
+	// the old "ping every neighbor every tick" loop.
+	vn.swimProbe()
+	vn.tickSuspicions()
+
 	// Check for new successor
 	if err := vn.checkNewSuccessor(); err != nil {
 		log.Printf("[ERR] Error checking for new successor: %s", err)
@@ -129,29 +142,30 @@ func (vn *localVnode) stabilize() {
 	vn.stabilized = time.Now()
 }
 
-func (vn *localVnode) keepalive() {
-	for {
-		for _, n := range vn.successors {
-			if n != nil && n.Host != vn.Host {
-				vn.ring.transport.Ping(n)
-			}
-		}
-
-		if vn.predecessor != nil && vn.predecessor.Host != vn.Host {
-			vn.ring.transport.Ping(vn.predecessor)
-		}
+// RPC: Ping is the bare liveness check SWIM's direct/indirect probing
+// is built on - if the handler runs at all, the vnode is alive.
+func (vn *localVnode) Ping() (bool, error) {
+	return true, nil
+}
 
-		for i, n := range vn.finger {
-			if i > 0 && vn.finger[i-1] == n {
-				continue
-			}
-			if n != nil && n.Host != vn.Host {
-				vn.ring.transport.Ping(n)
-			}
-		}
+// RPC: IndirectPing is invoked by a peer that failed to reach target
+// directly and wants us to relay a ping on its behalf.
+func (vn *localVnode) IndirectPing(target *Vnode) (bool, error) {
+	return vn.ring.transport.Ping(target)
+}
 
-		time.Sleep(10 * time.Second)
+// RPC: ListVnodes answers a diagnostics query with every vnode hosted by
+// this vnode's ring, subject to the same ACL as Ring.ListVnodes.
+func (vn *localVnode) ListVnodes(remoteHost string) ([]*Vnode, error) {
+	if !vn.allowRPC(remoteHost) {
+		return nil, ErrRateLimited
 	}
+	if !vn.getLimiter().tryAcquire() {
+		return nil, ErrBusy
+	}
+	defer vn.getLimiter().release()
+
+	return vn.ring.ListVnodes(remoteHost)
 }
 
 // Checks for a new successor
@@ -170,6 +184,12 @@ func (vn *localVnode) checkNewSuccessor() error {
 		if err == nil {
 			break
 		}
+		if err == ErrBusy || err == ErrRateLimited {
+			// Successor is overloaded, not dead - back off and retry
+			// sooner instead of evicting it from the successor list.
+			vn.requestFastRetry()
+			return nil
+		}
 		nlog.Warn("Get predecessor error:", err)
 		// alive, err := trans.Ping(succ)
 		// if err == nil && alive {
@@ -200,13 +220,19 @@ func (vn *localVnode) checkNewSuccessor() error {
 		maybe_suc, err = trans.GetPredecessor(succ)
 	}
 
-	// Check if we should replace our successor
-	if maybe_suc != nil && between(vn.Id, succ.Id, maybe_suc.Id) {
+	// Check if we should replace our successor. A fresher incarnation of
+	// the same Id replaces the stale one outright - between() alone
+	// would never trigger since the Ids, and thus ring positions, are
+	// identical.
+	if maybe_suc != nil && (between(vn.Id, succ.Id, maybe_suc.Id) || sameIdNewer(succ, maybe_suc)) {
 		// Check if new successor is alive before switching
 		alive, err := trans.Ping(maybe_suc)
 		if err != nil || !alive {
 			nlog.Warnf("Failed to contact potential new successor %s at %s", maybe_suc.String(), maybe_suc.Host)
-			vn.ring.transport.Notify(succ, &vn.Vnode)
+			_, peerGossip, notifyErr := vn.ring.transport.Notify(succ, &vn.Vnode, vn.pendingGossip())
+			if notifyErr == nil {
+				vn.mergeGossip(peerGossip)
+			}
 			return err
 		}
 		copy(vn.successors[1:], vn.successors[0:len(vn.successors)-1])
@@ -222,8 +248,17 @@ func (vn *localVnode) checkNewSuccessor() error {
 	return nil
 }
 
-// RPC: Invoked to return out predecessor
-func (vn *localVnode) GetPredecessor() (*Vnode, error) {
+// RPC: Invoked to return out predecessor. remoteHost is the caller's
+// address, used for per-source rate limiting.
+func (vn *localVnode) GetPredecessor(remoteHost string) (*Vnode, error) {
+	if !vn.allowRPC(remoteHost) {
+		return nil, ErrRateLimited
+	}
+	if !vn.getLimiter().tryAcquire() {
+		return nil, ErrBusy
+	}
+	defer vn.getLimiter().release()
+
 	return vn.predecessor, nil
 }
 
@@ -231,10 +266,18 @@ func (vn *localVnode) GetPredecessor() (*Vnode, error) {
 func (vn *localVnode) notifySuccessor() error {
 	// Notify successor
 	succ := vn.successors[0]
-	succ_list, err := vn.ring.transport.Notify(succ, &vn.Vnode)
+	succ_list, peerGossip, err := vn.ring.transport.Notify(succ, &vn.Vnode, vn.pendingGossip())
 	if err != nil {
+		if err == ErrBusy || err == ErrRateLimited {
+			// Successor is momentarily overloaded, not dead - just try
+			// again sooner instead of letting it fall out of the
+			// successor list.
+			vn.requestFastRetry()
+			return nil
+		}
 		return err
 	}
+	vn.mergeGossip(peerGossip)
 
 	// Trim the successors list if too long
 	max_succ := vn.ring.config.NumSuccessors
@@ -253,12 +296,30 @@ func (vn *localVnode) notifySuccessor() error {
 	return nil
 }
 
-// RPC: Notify is invoked when a Vnode gets notified
-func (vn *localVnode) Notify(maybe_pred *Vnode) ([]*Vnode, error) {
+// RPC: Notify is invoked when a Vnode gets notified. The incoming and
+// returned gossip slices piggyback SWIM suspicion/alive messages so they
+// spread through the ring alongside ordinary stabilize traffic.
+func (vn *localVnode) Notify(remoteHost string, maybe_pred *Vnode, gossip []gossipMsg) ([]*Vnode, []gossipMsg, error) {
+	if !vn.allowRPC(remoteHost) {
+		return nil, nil, ErrRateLimited
+	}
+	if !vn.getLimiter().tryAcquire() {
+		return nil, nil, ErrBusy
+	}
+	defer vn.getLimiter().release()
+
+	vn.mergeGossip(gossip)
+
+	vn.refuteSelfIfStale(maybe_pred)
+
 	shouldUpdate := false
 	// Check if we should update our predecessor
 	if vn.predecessor == nil || between(vn.predecessor.Id, vn.Id, maybe_pred.Id) {
 		shouldUpdate = true
+	} else if sameIdNewer(vn.predecessor, maybe_pred) {
+		// Same Id, fresher incarnation: the old instance is a zombie,
+		// don't wait for a ping timeout to replace it.
+		shouldUpdate = true
 	} else if CompareId(vn.predecessor.Id, maybe_pred.Id) != 0 {
 		alive, err := vn.ring.transport.Ping(vn.predecessor)
 		if err != nil || !alive {
@@ -278,7 +339,7 @@ func (vn *localVnode) Notify(maybe_pred *Vnode) ([]*Vnode, error) {
 	}
 
 	// Return our successors list
-	return vn.successors, nil
+	return vn.successors, vn.pendingGossip(), nil
 }
 
 func (vn *localVnode) fixFingerTableAtIndex(idx int) (int, error) {
@@ -287,7 +348,7 @@ func (vn *localVnode) fixFingerTableAtIndex(idx int) (int, error) {
 	offset := powerOffset(vn.Id, idx, hb)
 
 	// Find the successor
-	nodes, err := vn.FindSuccessors(1, offset)
+	nodes, err := vn.FindSuccessors(vn.Host, 1, offset)
 	if nodes == nil || len(nodes) == 0 || err != nil {
 		return idx, err
 	}
@@ -357,8 +418,19 @@ func (vn *localVnode) checkPredecessor() error {
 	return nil
 }
 
-// Finds next N successors. N must be <= NumSuccessors
-func (vn *localVnode) FindSuccessors(n int, key []byte) ([]*Vnode, error) {
+// Finds next N successors. N must be <= NumSuccessors. remoteHost is the
+// caller's address for rate limiting purposes; pass vn.Host for calls
+// originating locally (finger fixups, topic lookups) to exempt them.
+func (vn *localVnode) FindSuccessors(remoteHost string, n int, key []byte) ([]*Vnode, error) {
+	if remoteHost != vn.Host && !vn.allowRPC(remoteHost) {
+		return nil, ErrRateLimited
+	}
+
+	if !vn.getLimiter().tryAcquire() {
+		return nil, ErrBusy
+	}
+	defer vn.getLimiter().release()
+
 	if vn.successors == nil || len(vn.successors) == 0 {
 		return nil, errors.New("Successor list not initialized")
 	}
@@ -409,7 +481,7 @@ func (vn *localVnode) FindSuccessors(n int, key []byte) ([]*Vnode, error) {
 }
 
 func (vn *localVnode) FindPredecessor(key []byte) (*Vnode, error) {
-	vnodes, err := vn.FindSuccessors(1, key)
+	vnodes, err := vn.FindSuccessors(vn.Host, 1, key)
 	if err != nil {
 		return nil, err
 	}
@@ -453,7 +525,17 @@ func (vn *localVnode) leave() error {
 }
 
 // Used to clear our predecessor when a node is leaving
-func (vn *localVnode) ClearPredecessor(p *Vnode) error {
+func (vn *localVnode) ClearPredecessor(remoteHost string, p *Vnode) error {
+	if !vn.allowRPC(remoteHost) {
+		return ErrRateLimited
+	}
+	if !vn.getLimiter().tryAcquire() {
+		return ErrBusy
+	}
+	defer vn.getLimiter().release()
+
+	vn.refuteSelfIfStale(p)
+
 	if vn.predecessor != nil && vn.predecessor.String() == p.String() {
 		// Inform the delegate
 		conf := vn.ring.config
@@ -467,7 +549,17 @@ func (vn *localVnode) ClearPredecessor(p *Vnode) error {
 }
 
 // Used to skip a successor when a node is leaving
-func (vn *localVnode) SkipSuccessor(s *Vnode) error {
+func (vn *localVnode) SkipSuccessor(remoteHost string, s *Vnode) error {
+	if !vn.allowRPC(remoteHost) {
+		return ErrRateLimited
+	}
+	if !vn.getLimiter().tryAcquire() {
+		return ErrBusy
+	}
+	defer vn.getLimiter().release()
+
+	vn.refuteSelfIfStale(s)
+
 	// Skip if we have a match
 	if vn.successors[0].String() == s.String() {
 		// Inform the delegate